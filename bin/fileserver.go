@@ -3,16 +3,45 @@ Simple HTTP File Server for Personal Website Development
 
 This Go program creates a simple HTTP file server for serving static files
 during website development. It serves files from a specified directory
-and listens on localhost:3000.
+and listens on a configurable address and port.
 
 Usage:
-    go run fileserver.go <directory_path>
+    go run fileserver.go [-addr 127.0.0.1] [-port 3000] [-root docs/] [-quiet]
+        [-spa] [-spa-index index.html] [-log-format text] [-live-reload]
+        [-tls] [-cert cert.pem] [-key key.pem]
+        [-cors] [-cors-origin *] [-csp policy] [-no-cache]
+        [-hide-dotfiles] [-ignore glob,...] [-no-listing]
 
 Example:
-    go run fileserver.go docs/
+    go run fileserver.go -root docs/ -port 8080
+    go run fileserver.go -root build/ -spa
+    go run fileserver.go -root docs/ -live-reload
+    go run fileserver.go -root docs/ -tls
+    go run fileserver.go -root docs/ -cors -no-cache
+    go run fileserver.go -root docs/ -hide-dotfiles -ignore "*.bak,drafts/*"
 
-The server will serve files from the specified directory at:
-    http://127.0.0.1:3000
+All flags are optional: -root defaults to the current working directory and
+-addr/-port default to 127.0.0.1:3000, so `go run fileserver.go` with no
+arguments just works. Pass -addr 0.0.0.0 to bind on all interfaces for LAN
+testing (e.g. from a phone on the same network). Pass -spa when serving a
+client-side routed site (React/Vue/Svelte): unknown paths fall back to the
+SPA index instead of a 404, so the router can take over. Every request is
+logged with its method, path, status, size, and duration; pass
+-log-format json for machine-readable log lines instead of the default
+text format. Pass -live-reload to watch the served directory for changes
+(via fsnotify) and have connected browsers reload automatically. Pass -tls
+to serve over HTTPS; with no -cert/-key, a self-signed certificate for
+localhost/127.0.0.1 is generated in memory at startup, which is enough to
+test features that require a secure context such as service workers or
+WebCrypto. Pass -cors to send Access-Control-Allow-* headers (useful when
+the site fetches from a separate local API server), -csp to set a
+Content-Security-Policy, and -no-cache to send Cache-Control: no-store and
+suppress ETag so browsers don't serve stale assets between edits. Pass
+-hide-dotfiles and/or -ignore (a comma-separated glob list matched against
+each file's path relative to root, e.g. "drafts/*") to keep files out of
+both direct requests and directory listings; index-less directories get
+a listing page with file sizes, mtimes, and type icons, or pass -no-listing
+to 404 them instead.
 
 Author: Khanh
 Repository: fbundle.github.io
@@ -21,36 +50,730 @@ Repository: fbundle.github.io
 package main
 
 import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"html/template"
+	"io/fs"
 	"log"
+	"math/big"
+	"net"
 	"net/http"
 	"os"
-)
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
-const (
-	// Server address - listens on localhost port 3000
-	addr = "127.0.0.1:3000"
+	"github.com/fsnotify/fsnotify"
 )
 
+// spaHandler wraps a file-serving handler so that requests for paths that
+// don't resolve to an existing file are rewritten to the SPA index, letting
+// a client-side router handle the route instead of getting a 404.
+type spaHandler struct {
+	fs    http.FileSystem
+	inner http.Handler
+	index string
+}
+
+func (h spaHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.isAsset(r.URL.Path) {
+		h.inner.ServeHTTP(w, r)
+		return
+	}
+	h.serveIndex(w, r)
+}
+
+// serveIndex serves the SPA index directly via http.ServeContent rather
+// than delegating to the inner http.FileServer with a rewritten path: the
+// FileServer redirects bare "/index.html" requests to "./", which would
+// throw away the client-side route we're trying to preserve.
+func (h spaHandler) serveIndex(w http.ResponseWriter, r *http.Request) {
+	f, err := h.fs.Open("/" + h.index)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	http.ServeContent(w, r, h.index, info.ModTime(), f)
+}
+
+// isAsset reports whether the request path has a file extension or maps to
+// an existing file in the underlying filesystem. Paths that are neither are
+// assumed to be client-side routes and are rewritten to the SPA index.
+func (h spaHandler) isAsset(urlPath string) bool {
+	if path.Ext(urlPath) != "" {
+		return true
+	}
+	f, err := h.fs.Open(path.Clean(urlPath))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	return err == nil && !info.IsDir()
+}
+
+// responseWriter wraps an http.ResponseWriter to record the status code and
+// number of bytes written, so the logging middleware can report them after
+// the handler has finished.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	w.status = status
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+// loggingMiddleware wraps handler, emitting one log line per request with
+// the method, path, remote address, status, bytes written, and duration.
+func loggingMiddleware(handler http.Handler, format string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(rw, r)
+		duration := time.Since(start)
+
+		switch format {
+		case "json":
+			entry := map[string]interface{}{
+				"method":      r.Method,
+				"path":        r.URL.Path,
+				"remote_addr": r.RemoteAddr,
+				"status":      rw.status,
+				"bytes":       rw.bytes,
+				"duration_ms": duration.Milliseconds(),
+			}
+			b, err := json.Marshal(entry)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			log.Println(string(b))
+		default:
+			log.Printf("%s %s %s -> %d (%d bytes) in %s", r.RemoteAddr, r.Method, r.URL.Path, rw.status, rw.bytes, duration)
+		}
+	})
+}
+
+// reloadScript is injected into every text/html response when live-reload
+// is enabled. It opens an SSE connection to /__reload and reloads the page
+// whenever the server announces a change on the watched directory.
+const reloadScript = `<script>
+(function() {
+	var es = new EventSource('/__reload');
+	es.addEventListener('reload', function() { location.reload(); });
+})();
+</script>
+`
+
+// reloadBroker fans out filesystem-change notifications to every connected
+// /__reload SSE client.
+type reloadBroker struct {
+	mu      sync.Mutex
+	clients map[chan struct{}]bool
+}
+
+func newReloadBroker() *reloadBroker {
+	return &reloadBroker{clients: make(map[chan struct{}]bool)}
+}
+
+func (b *reloadBroker) subscribe() chan struct{} {
+	ch := make(chan struct{}, 1)
+	b.mu.Lock()
+	b.clients[ch] = true
+	b.mu.Unlock()
+	return ch
+}
+
+func (b *reloadBroker) unsubscribe(ch chan struct{}) {
+	b.mu.Lock()
+	delete(b.clients, ch)
+	b.mu.Unlock()
+}
+
+func (b *reloadBroker) broadcast() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.clients {
+		select {
+		case ch <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// reloadHandler serves the /__reload Server-Sent-Events endpoint that
+// browsers subscribe to via reloadScript.
+func reloadHandler(broker *reloadBroker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.WriteHeader(http.StatusOK)
+		flusher.Flush()
+
+		ch := broker.subscribe()
+		defer broker.unsubscribe(ch)
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ch:
+				fmt.Fprint(w, "event: reload\ndata: reload\n\n")
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// watchForChanges watches root recursively with fsnotify and calls
+// broker.broadcast after a ~100ms quiet period, coalescing the
+// rename+replace bursts editors tend to emit on save. It runs until the
+// watcher errors out, logging a message and returning if it can't start.
+func watchForChanges(root string, broker *reloadBroker) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Println("live-reload: could not start watcher:", err)
+		return
+	}
+	defer watcher.Close()
+
+	if err := addRecursive(watcher, root); err != nil {
+		log.Println("live-reload: could not watch", root, ":", err)
+		return
+	}
+
+	const debounce = 100 * time.Millisecond
+	var debounceTimer *time.Timer
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			// Watch newly created directories so the reload covers files
+			// added inside them too; fsnotify doesn't recurse on its own.
+			if event.Op&fsnotify.Create != 0 {
+				if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+					_ = watcher.Add(event.Name)
+				}
+			}
+			if debounceTimer != nil {
+				debounceTimer.Stop()
+			}
+			debounceTimer = time.AfterFunc(debounce, broker.broadcast)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Println("live-reload watcher error:", err)
+		}
+	}
+}
+
+// addRecursive adds root and every directory beneath it to watcher, since
+// fsnotify only watches the directories it's explicitly told about.
+func addRecursive(watcher *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			return watcher.Add(p)
+		}
+		return nil
+	})
+}
+
+// bufferingWriter captures a handler's response instead of writing it
+// straight through, so liveReloadMiddleware can inspect and rewrite HTML
+// bodies before they reach the client.
+type bufferingWriter struct {
+	header http.Header
+	status int
+	buf    bytes.Buffer
+}
+
+func (w *bufferingWriter) Header() http.Header         { return w.header }
+func (w *bufferingWriter) WriteHeader(status int)      { w.status = status }
+func (w *bufferingWriter) Write(b []byte) (int, error) { return w.buf.Write(b) }
+
+// liveReloadMiddleware buffers every response and, for text/html bodies,
+// injects reloadScript before </body> so the page picks up the SSE client.
+func liveReloadMiddleware(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		bw := &bufferingWriter{header: make(http.Header), status: http.StatusOK}
+		handler.ServeHTTP(bw, r)
+
+		body := bw.buf.Bytes()
+		if strings.HasPrefix(bw.header.Get("Content-Type"), "text/html") {
+			body = injectReloadScript(body)
+			bw.header.Del("Content-Length")
+			bw.header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+
+		for key, values := range bw.header {
+			for _, v := range values {
+				w.Header().Add(key, v)
+			}
+		}
+		w.WriteHeader(bw.status)
+		w.Write(body)
+	})
+}
+
+// injectReloadScript inserts reloadScript immediately before the last
+// </body> tag, or appends it to the end of the document if none is found.
+func injectReloadScript(body []byte) []byte {
+	const closingTag = "</body>"
+	idx := bytes.LastIndex(bytes.ToLower(body), []byte(closingTag))
+	if idx == -1 {
+		return append(body, []byte(reloadScript)...)
+	}
+	out := make([]byte, 0, len(body)+len(reloadScript))
+	out = append(out, body[:idx]...)
+	out = append(out, []byte(reloadScript)...)
+	out = append(out, body[idx:]...)
+	return out
+}
+
+// generateSelfSignedCert creates an in-memory, self-signed certificate and
+// key valid for localhost and 127.0.0.1, for use when -tls is set without
+// -cert/-key.
+func generateSelfSignedCert() (tls.Certificate, error) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{Organization: []string{"fileserver dev cert"}},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &priv.PublicKey, priv)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: priv}, nil
+}
+
+// headerOptions configures the headers written by headerMiddleware.
+type headerOptions struct {
+	cors       bool
+	corsOrigin string
+	csp        string
+	noCache    bool
+}
+
+// noETagWriter strips the ETag header that http.FileServer sets just before
+// the response is flushed, since it is set lazily during ServeContent and
+// can't simply be deleted up front.
+type noETagWriter struct {
+	http.ResponseWriter
+}
+
+func (w noETagWriter) WriteHeader(status int) {
+	w.Header().Del("Etag")
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w noETagWriter) Write(b []byte) (int, error) {
+	w.Header().Del("Etag")
+	return w.ResponseWriter.Write(b)
+}
+
+// headerMiddleware sets CORS, Content-Security-Policy, and cache-control
+// headers on every response according to opts.
+func headerMiddleware(handler http.Handler, opts headerOptions) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+
+		if opts.cors {
+			h.Set("Access-Control-Allow-Origin", opts.corsOrigin)
+			h.Set("Access-Control-Allow-Methods", "GET, HEAD, OPTIONS")
+			h.Set("Access-Control-Allow-Headers", "*")
+		}
+
+		if opts.csp != "" {
+			h.Set("Content-Security-Policy", opts.csp)
+		}
+
+		if opts.noCache {
+			h.Set("Cache-Control", "no-store")
+			w = noETagWriter{ResponseWriter: w}
+		}
+
+		if opts.cors && r.Method == http.MethodOptions {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// filteredFileSystem wraps an http.FileSystem to hide dotfiles and files
+// matching an ignore glob list, both from direct requests and from
+// directory listings.
+type filteredFileSystem struct {
+	http.FileSystem
+	hideDotfiles bool
+	ignore       []string
+}
+
+// isHidden reports whether relPath (rooted at the served directory, with no
+// leading slash; "" or "." for the root itself) should be hidden, either
+// because its base name starts with a dot (hideDotfiles) or because it
+// matches one of the ignore globs. A pattern containing a "/" is matched
+// against the full relative path, so "drafts/*" hides every file directly
+// inside a drafts/ directory; a pattern with no "/" is matched against just
+// the base name, so it applies anywhere in the tree, e.g. "*.bak" hides
+// backup files at any depth, not only at the root.
+func (fsys filteredFileSystem) isHidden(relPath string) bool {
+	relPath = strings.TrimPrefix(relPath, "/")
+	if relPath == "" || relPath == "." {
+		return false
+	}
+
+	base := path.Base(relPath)
+	if fsys.hideDotfiles && strings.HasPrefix(base, ".") {
+		return true
+	}
+	for _, pattern := range fsys.ignore {
+		if strings.Contains(pattern, "/") {
+			if ok, _ := path.Match(pattern, relPath); ok {
+				return true
+			}
+		} else if ok, _ := path.Match(pattern, base); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (fsys filteredFileSystem) Open(name string) (http.File, error) {
+	relPath := strings.TrimPrefix(path.Clean(name), "/")
+	if fsys.isHidden(relPath) {
+		return nil, fs.ErrNotExist
+	}
+	f, err := fsys.FileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	if relPath == "." {
+		relPath = ""
+	}
+	return filteredFile{File: f, fsys: fsys, dir: relPath}, nil
+}
+
+// filteredFile hides filtered entries from directory listings served
+// through its embedded http.File.
+type filteredFile struct {
+	http.File
+	fsys filteredFileSystem
+	dir  string // this directory's path relative to the served root, no leading slash
+}
+
+func (f filteredFile) Readdir(count int) ([]os.FileInfo, error) {
+	infos, err := f.File.Readdir(count)
+	if err != nil {
+		return nil, err
+	}
+	visible := infos[:0]
+	for _, info := range infos {
+		relPath := info.Name()
+		if f.dir != "" {
+			relPath = f.dir + "/" + info.Name()
+		}
+		if !f.fsys.isHidden(relPath) {
+			visible = append(visible, info)
+		}
+	}
+	return visible, nil
+}
+
+// listingEntry is one row rendered by listingTemplate.
+type listingEntry struct {
+	Name    string
+	Href    string
+	IsDir   bool
+	Icon    string
+	Size    string
+	ModTime string
+}
+
+var listingTemplate = template.Must(template.New("listing").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Index of {{.Path}}</title>
+</head>
+<body>
+<h1>Index of {{.Path}}</h1>
+<table>
+<tr><th></th><th>Name</th><th>Size</th><th>Modified</th></tr>
+{{if ne .Path "/"}}<tr><td></td><td><a href="../">../</a></td><td></td><td></td></tr>{{end}}
+{{range .Entries}}<tr><td>{{.Icon}}</td><td><a href="{{.Href}}">{{.Name}}</a></td><td>{{.Size}}</td><td>{{.ModTime}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// fileIcon returns a small icon for name's type, based on whether it's a
+// directory or its file extension.
+func fileIcon(name string, isDir bool) string {
+	if isDir {
+		return "\U0001F4C1"
+	}
+	switch strings.ToLower(filepath.Ext(name)) {
+	case ".html", ".htm":
+		return "\U0001F310"
+	case ".css":
+		return "\U0001F3A8"
+	case ".js":
+		return "\U0001F4DC"
+	case ".png", ".jpg", ".jpeg", ".gif", ".svg", ".webp":
+		return "\U0001F5BC"
+	case ".json", ".yaml", ".yml", ".toml":
+		return "\U0001F527"
+	default:
+		return "\U0001F4C4"
+	}
+}
+
+// humanSize renders n bytes as a short human-readable size, e.g. "12.3 KB".
+func humanSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// listingMiddleware renders a custom HTML directory listing for index-less
+// directories instead of the stdlib's plain one, or returns 404 for them
+// when noListing is set.
+func listingMiddleware(handler http.Handler, fsys http.FileSystem, noListing bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upath := "/" + strings.TrimPrefix(r.URL.Path, "/")
+		upath = path.Clean(upath)
+
+		f, err := fsys.Open(upath)
+		if err != nil {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		info, err := f.Stat()
+		if err != nil || !info.IsDir() {
+			f.Close()
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		if idx, err := fsys.Open(path.Join(upath, "index.html")); err == nil {
+			idx.Close()
+			f.Close()
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		defer f.Close()
+
+		if noListing {
+			http.NotFound(w, r)
+			return
+		}
+
+		infos, err := f.Readdir(-1)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sort.Slice(infos, func(i, j int) bool { return infos[i].Name() < infos[j].Name() })
+
+		entries := make([]listingEntry, 0, len(infos))
+		for _, info := range infos {
+			href := info.Name()
+			size := humanSize(info.Size())
+			if info.IsDir() {
+				href += "/"
+				size = ""
+			}
+			entries = append(entries, listingEntry{
+				Name:    info.Name(),
+				Href:    href,
+				IsDir:   info.IsDir(),
+				Icon:    fileIcon(info.Name(), info.IsDir()),
+				Size:    size,
+				ModTime: info.ModTime().Format("2006-01-02 15:04:05"),
+			})
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		listingTemplate.Execute(w, struct {
+			Path    string
+			Entries []listingEntry
+		}{Path: upath, Entries: entries})
+	})
+}
+
 func main() {
-	// Get the root directory from command line arguments
-	// The first argument should be the path to the directory to serve
-	if len(os.Args) < 2 {
-		log.Fatal("Usage: go run fileserver.go <directory_path>")
+	addr := flag.String("addr", "127.0.0.1", "address to listen on")
+	port := flag.Int("port", 3000, "port to listen on")
+	root := flag.String("root", "", "directory to serve (defaults to the current working directory)")
+	quiet := flag.Bool("quiet", false, "suppress startup log messages")
+	spa := flag.Bool("spa", false, "fall back to the SPA index for unknown paths instead of 404")
+	spaIndex := flag.String("spa-index", "index.html", "SPA index file served for unknown paths when -spa is set")
+	logFormat := flag.String("log-format", "text", "request log format: text or json")
+	liveReload := flag.Bool("live-reload", false, "watch root and reload connected browsers on change")
+	useTLS := flag.Bool("tls", false, "serve over HTTPS")
+	certPath := flag.String("cert", "", "TLS certificate file (generates a self-signed cert if omitted)")
+	keyPath := flag.String("key", "", "TLS key file (generates a self-signed cert if omitted)")
+	cors := flag.Bool("cors", false, "send Access-Control-Allow-* headers on every response")
+	corsOrigin := flag.String("cors-origin", "*", "value of Access-Control-Allow-Origin when -cors is set")
+	csp := flag.String("csp", "", "Content-Security-Policy header value to send on every response")
+	noCache := flag.Bool("no-cache", false, "send Cache-Control: no-store and suppress ETag on every response")
+	hideDotfiles := flag.Bool("hide-dotfiles", false, "hide dotfiles from requests and directory listings")
+	ignore := flag.String("ignore", "", "comma-separated glob list of base names to hide from requests and directory listings")
+	noListing := flag.Bool("no-listing", false, "return 404 for directories without an index.html instead of listing them")
+	flag.Parse()
+
+	if (*certPath == "") != (*keyPath == "") {
+		log.Fatal("-cert and -key must both be set, or both omitted to use a self-signed certificate")
 	}
-	rootDir := os.Args[1]
+
+	rootDir := *root
+	if rootDir == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			log.Fatal(err)
+		}
+		rootDir = wd
+	}
+
+	listenAddr := fmt.Sprintf("%s:%d", *addr, *port)
 
 	// Create a file server that serves files from the specified directory
-	fs := http.FileServer(http.Dir(rootDir))
+	var ignoreGlobs []string
+	if *ignore != "" {
+		ignoreGlobs = strings.Split(*ignore, ",")
+	}
+	var fsys http.FileSystem = filteredFileSystem{
+		FileSystem:   http.Dir(rootDir),
+		hideDotfiles: *hideDotfiles,
+		ignore:       ignoreGlobs,
+	}
+
+	var handler http.Handler = http.FileServer(fsys)
+	handler = listingMiddleware(handler, fsys, *noListing)
+	if *spa {
+		handler = spaHandler{fs: fsys, inner: handler, index: *spaIndex}
+	}
+
+	if *liveReload {
+		broker := newReloadBroker()
+		go watchForChanges(rootDir, broker)
+		handler = liveReloadMiddleware(handler)
+		http.Handle("/__reload", reloadHandler(broker))
+	}
+
+	handler = headerMiddleware(handler, headerOptions{
+		cors:       *cors,
+		corsOrigin: *corsOrigin,
+		csp:        *csp,
+		noCache:    *noCache,
+	})
+
+	handler = loggingMiddleware(handler, *logFormat)
 
 	// Handle all requests by serving files from the root directory
 	// StripPrefix removes the "/" prefix from requests before serving files
-	http.Handle("/", http.StripPrefix("/", fs))
+	http.Handle("/", http.StripPrefix("/", handler))
 
-	// Log that the server is starting and listen for connections
-	log.Println("Server is up:", addr)
-	log.Println("Serving files from:", rootDir)
-	log.Println("Access your website at: http://" + addr)
+	scheme := "http"
+	if *useTLS {
+		scheme = "https"
+	}
+
+	if !*quiet {
+		log.Println("Server is up:", listenAddr)
+		log.Println("Serving files from:", rootDir)
+		log.Println("Access your website at: " + scheme + "://" + listenAddr)
+	}
+
+	if !*useTLS {
+		// Start the HTTP server and log any fatal errors
+		log.Fatal(http.ListenAndServe(listenAddr, nil))
+	}
+
+	server := &http.Server{Addr: listenAddr}
+	if *certPath == "" && *keyPath == "" {
+		cert, err := generateSelfSignedCert()
+		if err != nil {
+			log.Fatal(err)
+		}
+		server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+		if !*quiet {
+			log.Println("Using an in-memory self-signed certificate for localhost/127.0.0.1")
+		}
+	}
 
-	// Start the HTTP server and log any fatal errors
-	log.Fatal(http.ListenAndServe(addr, nil))
+	// Start the HTTPS server and log any fatal errors
+	log.Fatal(server.ListenAndServeTLS(*certPath, *keyPath))
 }